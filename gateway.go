@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Sample is a single timestamped RTT observation.
+type Sample struct {
+	Host      string        `json:"host"`
+	Proto     string        `json:"proto"`
+	Timestamp time.Time     `json:"timestamp"`
+	RTT       time.Duration `json:"rtt_ns"`
+	Lost      bool          `json:"lost,omitempty"`
+}
+
+func findHostStats(stats []*HostStats, host string) *HostStats {
+	for _, s := range stats {
+		if s.Host == host {
+			return s
+		}
+	}
+	return nil
+}
+
+// samplesSince returns s's ring-buffered samples with timestamp in
+// [since, until], both bounds inclusive; a zero since/until leaves that
+// bound open.
+func samplesSince(s *HostStats, since, until time.Time) []Sample {
+	entries := s.ring.Snapshot()
+	out := make([]Sample, 0, len(entries))
+	for _, e := range entries {
+		if !since.IsZero() && e.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && e.Timestamp.After(until) {
+			continue
+		}
+		out = append(out, Sample{Host: s.Host, Proto: s.Proto, Timestamp: e.Timestamp, RTT: e.RTT})
+	}
+	return out
+}
+
+// historicalSamples answers a GetStats-style query for s, falling back to
+// store.Backfill when since predates the in-memory ring and deduping the
+// merged result by timestamp.
+func historicalSamples(s *HostStats, since, until time.Time) ([]Sample, error) {
+	entries := s.ring.Snapshot()
+
+	needsBackfill := since.IsZero() || len(entries) == 0 || since.Before(entries[0].Timestamp)
+	if !needsBackfill {
+		return samplesSince(s, since, until), nil
+	}
+
+	persisted, err := store.Backfill(s.Host, since)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Sample, 0, len(persisted)+len(entries))
+	seen := make(map[time.Time]bool, len(persisted))
+	for _, sample := range persisted {
+		if !until.IsZero() && sample.Timestamp.After(until) {
+			continue
+		}
+		out = append(out, sample)
+		seen[sample.Timestamp] = true
+	}
+	for _, sample := range samplesSince(s, since, until) {
+		if seen[sample.Timestamp] {
+			continue
+		}
+		out = append(out, sample)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out, nil
+}
+
+// newSamplesSince returns s's samples observed strictly after *cursor,
+// oldest first, and advances *cursor to the newest timestamp returned.
+func newSamplesSince(s *HostStats, cursor *time.Time) []Sample {
+	fresh := samplesSince(s, *cursor, time.Time{})
+	out := make([]Sample, 0, len(fresh))
+	for _, sample := range fresh {
+		if !cursor.IsZero() && !sample.Timestamp.After(*cursor) {
+			continue
+		}
+		out = append(out, sample)
+	}
+	if len(out) > 0 {
+		*cursor = out[len(out)-1].Timestamp
+	}
+	return out
+}
+
+// registerGatewayRoutes mounts the REST/JSON query API: ListHosts,
+// GetStats and a streaming WatchStats.
+func registerGatewayRoutes(mux *http.ServeMux, stats []*HostStats) {
+	mux.HandleFunc("/v1/hosts", func(w http.ResponseWriter, r *http.Request) {
+		hosts := make([]string, 0, len(stats))
+		for _, s := range stats {
+			hosts = append(hosts, s.Host)
+		}
+		writeJSON(w, map[string]any{"hosts": hosts})
+	})
+
+	mux.HandleFunc("/v1/stats", func(w http.ResponseWriter, r *http.Request) {
+		host := r.URL.Query().Get("host")
+		s := findHostStats(stats, host)
+		if s == nil {
+			http.Error(w, "unknown host", http.StatusNotFound)
+			return
+		}
+		since := parseRFC3339(r.URL.Query().Get("since"))
+		until := parseRFC3339(r.URL.Query().Get("until"))
+		samples, err := historicalSamples(s, since, until)
+		if err != nil {
+			http.Error(w, "backfill failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]any{"host": host, "samples": samples})
+	})
+
+	mux.HandleFunc("/v1/stats/watch", func(w http.ResponseWriter, r *http.Request) {
+		host := r.URL.Query().Get("host")
+		s := findHostStats(stats, host)
+		if s == nil {
+			http.Error(w, "unknown host", http.StatusNotFound)
+			return
+		}
+		streamSamples(w, r, s)
+	})
+}
+
+// streamSamples pushes newly observed samples for s to w as
+// newline-delimited JSON until the client disconnects.
+func streamSamples(w http.ResponseWriter, r *http.Request, s *HostStats) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	enc := json.NewEncoder(w)
+	var lastTS time.Time
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			for _, sample := range newSamplesSince(s, &lastTS) {
+				if err := enc.Encode(sample); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func parseRFC3339(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// SyslogConfig enables forwarding log records to a syslog daemon in
+// addition to the regular stdout handler.
+type SyslogConfig struct {
+	Network string `yaml:"network"` // "udp", "tcp", or "" for local unix socket
+	Address string `yaml:"address"` // empty for local syslog
+	Tag     string `yaml:"tag"`
+}
+
+// logger defaults to a plain stderr text handler so that fatal() calls
+// made before initLogger runs (e.g. from loadConfig, which parses
+// --log-level/--log-format themselves) still emit structured fields
+// instead of silently dropping them.
+var (
+	logger    = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	logCloser io.Closer
+)
+
+// initLogger builds the package-level structured logger from the
+// resolved config, wiring in an optional syslog hook. It must be called
+// once, after flags have been merged into cfg.
+func initLogger(c Config) *slog.Logger {
+	level := parseLogLevel(c.LogLevel)
+
+	handlers := []slog.Handler{newHandler(os.Stdout, c.LogFormat, level)}
+
+	if c.Syslog != nil {
+		w, err := dialSyslog(*c.Syslog)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "syslog hook disabled: %v\n", err)
+		} else {
+			logCloser = w
+			handlers = append(handlers, newHandler(w, c.LogFormat, level))
+		}
+	}
+
+	var h slog.Handler
+	if len(handlers) == 1 {
+		h = handlers[0]
+	} else {
+		h = multiHandler(handlers)
+	}
+
+	l := slog.New(h)
+	slog.SetDefault(l)
+	return l
+}
+
+func newHandler(w io.Writer, format string, level slog.Level) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+func parseLogLevel(s string) slog.Level {
+	switch s {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// multiHandler fans a record out to several slog.Handlers, e.g. stdout
+// plus a syslog hook.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// closeLogger releases the syslog connection held by the active logger,
+// if any.
+func closeLogger() {
+	if logCloser != nil {
+		logCloser.Close()
+		logCloser = nil
+	}
+}
+
+// fatal logs msg at error level with the given fields, cleans up any
+// running terminal UI so the shell is left in a sane state, then exits.
+// This replaces the bare log.Fatalf calls that used to leave
+// termui/tcell mid-render on a fatal config error.
+func fatal(msg string, args ...any) {
+	if logger != nil {
+		logger.Error(msg, args...)
+	} else {
+		fmt.Fprintln(os.Stderr, append([]any{msg}, args...)...)
+	}
+	shutdownUI()
+	closeLogger()
+	os.Exit(1)
+}
@@ -1,11 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
-	"net"
 	"os"
 	"os/signal"
 	"sort"
@@ -17,32 +16,68 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
-	"golang.org/x/net/icmp"
-	"golang.org/x/net/ipv4"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Hosts    []string      `yaml:"hosts"`
-	Interval time.Duration `yaml:"interval"`
-	Timeout  time.Duration `yaml:"timeout"`
-	UseICMP  bool          `yaml:"use_icmp"`
+	Hosts          []HostConfig      `yaml:"hosts"`
+	Interval       time.Duration     `yaml:"interval"`
+	Timeout        time.Duration     `yaml:"timeout"`
+	UseICMP        bool              `yaml:"use_icmp"`
+	LogLevel       string            `yaml:"log_level"`
+	LogFormat      string            `yaml:"log_format"`
+	Syslog         *SyslogConfig     `yaml:"syslog"`
+	Listen         string            `yaml:"listen"`
+	MetricsBuckets []float64         `yaml:"metrics_buckets"`
+	HistorySize    int               `yaml:"history_size"`
+	EWMAAlpha      float64           `yaml:"ewma_alpha"`
+	Mode           string            `yaml:"mode"` // "" for normal probing, "mtr" for hop-by-hop traceroute
+	Persistence    PersistenceConfig `yaml:"persistence"`
 }
 
+const (
+	defaultHistorySize = 1000
+	defaultEWMAAlpha   = 0.2
+)
+
 type HostStats struct {
 	Host       string
+	Proto      string
 	Latency    time.Duration
 	PacketLoss float64
-	Timestamps []time.Time
-	RTTs       []time.Duration
+	ring       *RTTRingBuffer
+	rolling    *RollingStats
 	mutex      sync.Mutex
+
+	// Hops holds per-hop stats when running in --traceroute/mtr mode.
+	Hops      []*HopStats
+	hopsMutex sync.Mutex
+	routeHash uint64
+}
+
+func NewHostStats(host, proto string, historySize int, ewmaAlpha float64) *HostStats {
+	return &HostStats{
+		Host:    host,
+		Proto:   proto,
+		ring:    NewRTTRingBuffer(historySize),
+		rolling: NewRollingStats(ewmaAlpha),
+	}
 }
 
 var (
-	run      = true
-	cfg      Config
-	showHelp = flag.Bool("help", false, "Show usage instructions")
-	config   = flag.String("config", "config.yaml", "Path to YAML config file")
+	run        = true
+	cfg        Config
+	store      Store
+	showHelp   = flag.Bool("help", false, "Show usage instructions")
+	config     = flag.String("config", "config.yaml", "Path to YAML config file")
+	logLevel   = flag.String("log-level", "", "Log level: debug|info|warn|error (overrides config)")
+	logFormat  = flag.String("log-format", "", "Log format: text|json (overrides config)")
+	listen     = flag.String("listen", "", "HTTP address for /metrics and the REST gateway, e.g. :9100 (overrides config)")
+	traceroute = flag.Bool("traceroute", false, "Run in hop-by-hop traceroute/mtr mode (overrides config mode: mtr)")
+
+	uiMutex   sync.Mutex
+	uiScreen  tcell.Screen
+	uiRunning bool
 )
 
 func printHelp() {
@@ -51,16 +86,52 @@ func printHelp() {
 A real-time network latency visualizer with terminal UI.
 
 Options:
-  -config string   Path to YAML config file (default "config.yaml")
-  -help            Show this help manual
+  -config string       Path to YAML config file (default "config.yaml")
+  -log-level string    Log level: debug|info|warn|error (overrides config)
+  -log-format string   Log format: text|json (overrides config)
+  -listen string       HTTP address for /metrics and the REST gateway (overrides config)
+  -traceroute          Hop-by-hop traceroute/mtr mode (overrides config mode: mtr)
+  -help                Show this help manual
 
 YAML Config Example:
   hosts:
-    - google.com
-    - github.com:443
+    - google.com:443               # bare string, defaults to tcp (or icmp if use_icmp is set)
+    - addr: github.com
+      proto: https
+      path: /status
+      expected_status: [200, 204]
+      measure: full_body              # "ttfb" (default) or "full_body"
+    - addr: example.com        # name to look up
+      proto: dns
+      resolver: 1.1.1.1:53      # omit to use the system resolver
+      record_type: A
+    - addr: example.com:443
+      proto: quic
+      sni: example.com
+      alpn: [h3]            # defaults to h3 if omitted; set this if the target speaks a non-HTTP/3 QUIC protocol
   interval: 1s
   timeout: 1s
-  use_icmp: true
+  use_icmp: false
+  history_size: 1000    # samples retained per host for the plot and history queries (rolling stats cover all samples)
+  ewma_alpha: 0.2        # smoothing factor for EWMA RTT/loss and MOS
+  mode: mtr              # omit for normal probing, "mtr" for hop-by-hop traceroute
+  log_level: info
+  log_format: text
+  listen: ":9100"
+  metrics_buckets: [.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10]  # latency_rtt_seconds histogram, omit for the default
+  syslog:
+    network: udp
+    address: 127.0.0.1:514
+    tag: latency-visualizer
+  persistence:
+    backend: ndjson        # "ndjson", "sqlite", or omit/"none" for in-memory only
+    path: latency_log.ndjson
+    rotate_size_bytes: 104857600
+    rotate_interval: 24h
+    flush_interval: 5s
+    webhook:              # plain JSON POST per sample, NOT Prometheus remote_write
+      url: http://localhost:8080/ingest
+      timeout: 5s
 
 Controls:
   q        Quit the graph interface
@@ -72,108 +143,94 @@ On exit, latency data is saved to "latency_log.json".
 func loadConfig(path string) Config {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Failed to read config: %v", err)
+		fatal("failed to read config", "path", path, "err", err)
 	}
 	var c Config
 	err = yaml.Unmarshal(data, &c)
 	if err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+		fatal("failed to parse config", "path", path, "err", err)
 	}
-	return c
-}
-
-func resolveHost(host string) (string, error) {
-	ips, err := net.LookupIP(host)
-	if err != nil || len(ips) == 0 {
-		return "", err
+	if *logLevel != "" {
+		c.LogLevel = *logLevel
 	}
-	return ips[0].String(), nil
-}
-
-func pingICMP(host string, stats *HostStats) {
-	ip, err := resolveHost(host)
-	if err != nil {
-		log.Printf("Resolve error for %s: %v\n", host, err)
-		return
+	if *logFormat != "" {
+		c.LogFormat = *logFormat
 	}
-
-	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
-	if err != nil {
-		log.Printf("ICMP ListenPacket error: %v\n", err)
-		return
+	if *listen != "" {
+		c.Listen = *listen
+	}
+	if *traceroute {
+		c.Mode = "mtr"
+	}
+	for i := range c.Hosts {
+		c.Hosts[i].applyLegacyDefault(c.UseICMP)
+	}
+	if c.HistorySize == 0 {
+		c.HistorySize = defaultHistorySize
 	}
-	defer conn.Close()
+	if c.EWMAAlpha == 0 {
+		c.EWMAAlpha = defaultEWMAAlpha
+	}
+	return c
+}
 
-	seq := 0
+// runProbeLoop repeatedly invokes prober at cfg.Interval, recording each
+// round trip (or failure) into stats and into the Prometheus exporter.
+func runProbeLoop(hc HostConfig, prober Prober, stats *HostStats) {
 	sent := 0
 	received := 0
 
 	for run {
-		msg := icmp.Message{
-			Type: ipv4.ICMPTypeEcho,
-			Code: 0,
-			Body: &icmp.Echo{
-				ID:   os.Getpid() & 0xffff,
-				Seq:  seq,
-				Data: []byte("PING"),
-			},
-		}
-		seq++
-		b, _ := msg.Marshal(nil)
-		start := time.Now()
-		conn.SetDeadline(time.Now().Add(cfg.Timeout))
-		_, err = conn.WriteTo(b, &net.IPAddr{IP: net.ParseIP(ip)})
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 		sent++
-		if err != nil {
-			time.Sleep(cfg.Interval)
-			continue
-		}
+		rtt, err := prober.Probe(ctx)
+		cancel()
 
-		resp := make([]byte, 1500)
-		_, _, err = conn.ReadFrom(resp)
-		if err == nil {
-			received++
-			rtt := time.Since(start)
+		if err != nil {
 			stats.mutex.Lock()
-			stats.RTTs = append(stats.RTTs, rtt)
-			stats.Latency = rtt
-			stats.Timestamps = append(stats.Timestamps, time.Now())
 			stats.PacketLoss = float64(sent-received) / float64(sent) * 100
+			loss := stats.PacketLoss
 			stats.mutex.Unlock()
+			stats.rolling.Record(false, 0)
+			recordProbe(hc.Addr, hc.Proto, 0, false, loss)
+			store.Append(Sample{Host: hc.Addr, Proto: hc.Proto, Timestamp: time.Now(), Lost: true})
+			logger.Warn("probe failed", "host", hc.Addr, "proto", hc.Proto, "seq", sent, "err", err)
+			time.Sleep(cfg.Interval)
+			continue
 		}
-		time.Sleep(cfg.Interval)
-	}
-}
 
-func pingTCP(host string, stats *HostStats) {
-	sent := 0
-	received := 0
-	for run {
-		start := time.Now()
-		sent++
-		conn, err := net.DialTimeout("tcp", host, cfg.Timeout)
-		if err == nil {
-			received++
-			rtt := time.Since(start)
-			conn.Close()
-			stats.mutex.Lock()
-			stats.RTTs = append(stats.RTTs, rtt)
-			stats.Latency = rtt
-			stats.Timestamps = append(stats.Timestamps, time.Now())
-			stats.PacketLoss = float64(sent-received) / float64(sent) * 100
-			stats.mutex.Unlock()
-		}
+		received++
+		now := time.Now()
+		stats.ring.Append(now, rtt)
+		stats.rolling.Record(true, rtt)
+		stats.mutex.Lock()
+		stats.Latency = rtt
+		stats.PacketLoss = float64(sent-received) / float64(sent) * 100
+		loss := stats.PacketLoss
+		stats.mutex.Unlock()
+		recordProbe(hc.Addr, hc.Proto, rtt, true, loss)
+		store.Append(Sample{Host: hc.Addr, Proto: hc.Proto, Timestamp: now, RTT: rtt})
+		logger.Debug("probe", "host", hc.Addr, "proto", hc.Proto, "seq", sent, "rtt_ms", rtt.Milliseconds())
+
 		time.Sleep(cfg.Interval)
 	}
 }
 
 func displayGraph(stats []*HostStats) {
 	if err := termui.Init(); err != nil {
-		log.Printf("Graph display error, falling back to CLI: %v", err)
+		logger.Warn("graph display unavailable, falling back to CLI", "err", err)
 		displayLoop(stats)
 		return
 	}
-	defer termui.Close()
+	uiMutex.Lock()
+	uiRunning = true
+	uiMutex.Unlock()
+	defer func() {
+		uiMutex.Lock()
+		uiRunning = false
+		uiMutex.Unlock()
+		termui.Close()
+	}()
 
 	plot := widgets.NewPlot()
 	plot.Title = "Latency (ms)"
@@ -188,6 +245,13 @@ func displayGraph(stats []*HostStats) {
 	legend.SetRect(0, 20, 100, 25)
 	legend.TextStyle.Fg = termui.ColorCyan
 
+	statsTable := widgets.NewTable()
+	statsTable.Title = "Rolling Stats"
+	statsTable.SetRect(0, 25, 100, 26+len(stats))
+	statsTable.Rows = [][]string{statsTableHeader()}
+	statsTable.RowSeparator = false
+	statsTable.FillRow = true
+
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
@@ -196,21 +260,23 @@ func displayGraph(stats []*HostStats) {
 		select {
 		case <-ticker.C:
 			legendText := ""
+			rows := [][]string{statsTableHeader()}
 			for i, s := range stats {
-				s.mutex.Lock()
-				if len(s.RTTs) > 50 {
-					s.RTTs = s.RTTs[len(s.RTTs)-50:]
+				entries := s.ring.Snapshot()
+				if len(entries) > 50 {
+					entries = entries[len(entries)-50:]
 				}
-				slice := make([]float64, len(s.RTTs))
-				for j, d := range s.RTTs {
-					slice[j] = float64(d.Milliseconds())
+				slice := make([]float64, len(entries))
+				for j, e := range entries {
+					slice[j] = float64(e.RTT.Milliseconds())
 				}
 				plot.Data[i] = slice
 				legendText += fmt.Sprintf("%d. %s\n", i+1, s.Host)
-				s.mutex.Unlock()
+				rows = append(rows, statsTableRow(s))
 			}
 			legend.Text = legendText
-			termui.Render(plot, legend)
+			statsTable.Rows = rows
+			termui.Render(plot, legend, statsTable)
 		case e := <-uiEvents:
 			if e.Type == termui.KeyboardEvent && e.ID == "q" {
 				run = false
@@ -222,11 +288,19 @@ func displayGraph(stats []*HostStats) {
 func displayLoop(stats []*HostStats) {
 	screen, err := tcell.NewScreen()
 	if err != nil {
-		log.Printf("Fallback display failed: %v", err)
+		logger.Error("fallback display failed", "err", err)
 		return
 	}
 	screen.Init()
-	defer screen.Fini()
+	uiMutex.Lock()
+	uiScreen = screen
+	uiMutex.Unlock()
+	defer func() {
+		uiMutex.Lock()
+		uiScreen = nil
+		uiMutex.Unlock()
+		screen.Fini()
+	}()
 
 	for run {
 		screen.Clear()
@@ -258,23 +332,72 @@ func drawText(s tcell.Screen, x, y int, style tcell.Style, txt ...string) {
 	}
 }
 
+// hostLogEntry is the per-host record written to latency_log.json: the
+// retained RTT window plus the rolling statistics computed over the
+// full history.
+type hostLogEntry struct {
+	Samples []Sample        `json:"samples"`
+	Stats   RollingSnapshot `json:"stats"`
+}
+
 func saveLog(stats []*HostStats) {
 	f, err := os.Create("latency_log.json")
 	if err != nil {
-		log.Printf("Log save error: %v\n", err)
+		logger.Error("log save failed", "err", err)
 		return
 	}
 	defer f.Close()
 
-	data := make(map[string][]time.Duration)
+	data := make(map[string]hostLogEntry)
 	for _, s := range stats {
-		s.mutex.Lock()
-		data[s.Host] = s.RTTs
-		s.mutex.Unlock()
+		data[s.Host] = hostLogEntry{
+			Samples: samplesSince(s, time.Time{}, time.Time{}),
+			Stats:   s.rolling.Snapshot(),
+		}
 	}
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	enc.Encode(data)
+	if err := enc.Encode(data); err != nil {
+		logger.Error("log encode failed", "err", err)
+		return
+	}
+	logger.Info("latency log saved", "path", "latency_log.json", "hosts", len(stats))
+}
+
+// backfillHost reseeds stat's ring buffer and rolling stats from
+// persisted history, so the plot and exporter don't start empty after a
+// restart. It runs once at startup, before the prober goroutine begins
+// appending live samples.
+func backfillHost(stat *HostStats) {
+	samples, err := store.Backfill(stat.Host, time.Time{})
+	if err != nil {
+		logger.Warn("persistence backfill failed", "host", stat.Host, "err", err)
+		return
+	}
+	for _, s := range samples {
+		stat.rolling.Record(!s.Lost, s.RTT)
+		if !s.Lost {
+			stat.ring.Append(s.Timestamp, s.RTT)
+		}
+	}
+	if len(samples) > 0 {
+		logger.Info("backfilled host history", "host", stat.Host, "samples", len(samples))
+	}
+}
+
+// shutdownUI closes whichever terminal UI is currently active so a
+// fatal error never leaves the terminal in raw/alternate-screen mode.
+func shutdownUI() {
+	uiMutex.Lock()
+	defer uiMutex.Unlock()
+	if uiRunning {
+		termui.Close()
+		uiRunning = false
+	}
+	if uiScreen != nil {
+		uiScreen.Fini()
+		uiScreen = nil
+	}
 }
 
 func main() {
@@ -285,25 +408,57 @@ func main() {
 	}
 
 	cfg = loadConfig(*config)
+	logger = initLogger(cfg)
+	defer closeLogger()
+
+	var err error
+	store, err = newStore(cfg.Persistence)
+	if err != nil {
+		fatal("failed to init persistence", "backend", cfg.Persistence.Backend, "err", err)
+	}
+	defer store.Close()
 
 	var wg sync.WaitGroup
 	allStats := []*HostStats{}
 
-	for _, host := range cfg.Hosts {
-		stat := &HostStats{Host: host}
+	for _, hc := range cfg.Hosts {
+		stat := NewHostStats(hc.Addr, hc.Proto, cfg.HistorySize, cfg.EWMAAlpha)
+		backfillHost(stat)
 		allStats = append(allStats, stat)
+
+		if cfg.Mode == "mtr" {
+			wg.Add(1)
+			go func(h HostConfig, s *HostStats) {
+				defer wg.Done()
+				runTraceroute(h, s)
+			}(hc, stat)
+			continue
+		}
+
+		prober, err := newProber(hc)
+		if err != nil {
+			logger.Error("failed to start prober", "host", hc.Addr, "proto", hc.Proto, "err", err)
+			continue
+		}
+
 		wg.Add(1)
-		go func(h string, s *HostStats) {
+		go func(h HostConfig, p Prober, s *HostStats) {
 			defer wg.Done()
-			if cfg.UseICMP {
-				pingICMP(h, s)
-			} else {
-				pingTCP(h, s)
-			}
-		}(host, stat)
+			runProbeLoop(h, p, s)
+		}(hc, prober, stat)
 	}
 
-	go displayGraph(allStats)
+	if cfg.Listen != "" {
+		initMetrics(cfg.MetricsBuckets)
+		if err := startMetricsServer(cfg.Listen, allStats); err != nil {
+			logger.Error("failed to start metrics server", "addr", cfg.Listen, "err", err)
+		}
+	}
+	if cfg.Mode == "mtr" {
+		go displayTraceroute(allStats)
+	} else {
+		go displayGraph(allStats)
+	}
 
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var defaultRTTBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	rttSeconds *prometheus.HistogramVec
+
+	packetLossRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "packet_loss_ratio",
+		Help: "Fraction of probes sent to a host that have gone unanswered, 0..1.",
+	}, []string{"host", "proto"})
+
+	probesSentTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "probes_sent_total",
+		Help: "Total number of probes sent per host/protocol.",
+	}, []string{"host", "proto"})
+
+	probesReceivedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "probes_received_total",
+		Help: "Total number of probe responses received per host/protocol.",
+	}, []string{"host", "proto"})
+)
+
+// initMetrics builds rttSeconds with the given histogram buckets
+// (defaultRTTBuckets if empty) and registers all four series.
+func initMetrics(rttBuckets []float64) {
+	if len(rttBuckets) == 0 {
+		rttBuckets = defaultRTTBuckets
+	}
+	rttSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "latency_rtt_seconds",
+		Help:    "Round-trip time of a single probe, in seconds.",
+		Buckets: rttBuckets,
+	}, []string{"host", "proto"})
+	prometheus.MustRegister(rttSeconds, packetLossRatio, probesSentTotal, probesReceivedTotal)
+}
+
+// recordProbe updates the exported Prometheus series for a single probe
+// attempt. It is called right after a HostStats update so the exporter
+// and the in-memory stats never disagree.
+func recordProbe(host, proto string, rtt time.Duration, received bool, lossRatio float64) {
+	probesSentTotal.WithLabelValues(host, proto).Inc()
+	if received {
+		probesReceivedTotal.WithLabelValues(host, proto).Inc()
+		rttSeconds.WithLabelValues(host, proto).Observe(rtt.Seconds())
+	}
+	packetLossRatio.WithLabelValues(host, proto).Set(lossRatio / 100)
+}
+
+// startMetricsServer exposes /metrics (Prometheus text exposition) and
+// the REST/JSON gateway on addr. It returns once the listener is ready;
+// serving happens on a background goroutine.
+func startMetricsServer(addr string, stats []*HostStats) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	registerGatewayRoutes(mux, stats)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server stopped", "addr", addr, "err", err)
+		}
+	}()
+	logger.Info("metrics server listening", "addr", addr)
+	return nil
+}
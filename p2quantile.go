@@ -0,0 +1,103 @@
+package main
+
+import "sort"
+
+// p2Estimator is a streaming quantile estimator implementing the P²
+// ("Piecewise-Parabolic") algorithm (Jain & Chlamtac, 1985). It tracks
+// one quantile in O(1) space and time per observation, without storing
+// or sorting the full sample set.
+type p2Estimator struct {
+	p     float64
+	n     [5]float64 // marker positions
+	ns    [5]float64 // desired marker positions
+	dns   [5]float64 // desired position increments
+	q     [5]float64 // marker heights (the estimate lives in q[2])
+	count int
+}
+
+func newP2Estimator(p float64) *p2Estimator {
+	return &p2Estimator{
+		p:   p,
+		dns: [5]float64{0, p / 2, p, (1 + p) / 2, 1},
+	}
+}
+
+func (e *p2Estimator) Add(x float64) {
+	e.count++
+	if e.count <= 5 {
+		e.q[e.count-1] = x
+		if e.count == 5 {
+			sort.Float64s(e.q[:])
+			for i := range e.n {
+				e.n[i] = float64(i + 1)
+			}
+			e.ns = [5]float64{1, 1 + 2*e.p, 1 + 4*e.p, 3 + 2*e.p, 5}
+		}
+		return
+	}
+
+	k := 0
+	switch {
+	case x < e.q[0]:
+		e.q[0] = x
+	case x >= e.q[4]:
+		e.q[4] = x
+		k = 3
+	default:
+		for i := 0; i < 4; i++ {
+			if e.q[i] <= x && x < e.q[i+1] {
+				k = i
+				break
+			}
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		e.n[i]++
+	}
+	for i := 0; i < 5; i++ {
+		e.ns[i] += e.dns[i]
+	}
+
+	for i := 1; i < 4; i++ {
+		d := e.ns[i] - e.n[i]
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			sign := 1.0
+			if d < 0 {
+				sign = -1.0
+			}
+			qNew := e.parabolic(i, sign)
+			if e.q[i-1] < qNew && qNew < e.q[i+1] {
+				e.q[i] = qNew
+			} else {
+				e.q[i] = e.linear(i, sign)
+			}
+			e.n[i] += sign
+		}
+	}
+}
+
+func (e *p2Estimator) parabolic(i int, d float64) float64 {
+	return e.q[i] + d/(e.n[i+1]-e.n[i-1])*((e.n[i]-e.n[i-1]+d)*(e.q[i+1]-e.q[i])/(e.n[i+1]-e.n[i])+
+		(e.n[i+1]-e.n[i]-d)*(e.q[i]-e.q[i-1])/(e.n[i]-e.n[i-1]))
+}
+
+func (e *p2Estimator) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return e.q[i] + d*(e.q[j]-e.q[i])/(e.n[j]-e.n[i])
+}
+
+// Value returns the current quantile estimate, or 0 before any samples
+// have been added.
+func (e *p2Estimator) Value() float64 {
+	if e.count == 0 {
+		return 0
+	}
+	if e.count <= 5 {
+		sorted := append([]float64(nil), e.q[:e.count]...)
+		sort.Float64s(sorted)
+		idx := int(e.p * float64(e.count-1))
+		return sorted[idx]
+	}
+	return e.q[2]
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestP2EstimatorAgainstSortedBaseline(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float64, 5000)
+	for i := range samples {
+		samples[i] = rng.Float64() * 1000
+	}
+
+	for _, p := range []float64{0.5, 0.9, 0.95, 0.99} {
+		e := newP2Estimator(p)
+		for _, x := range samples {
+			e.Add(x)
+		}
+
+		sorted := append([]float64(nil), samples...)
+		sort.Float64s(sorted)
+		want := sorted[int(p*float64(len(sorted)-1))]
+
+		got := e.Value()
+		tolerance := want * 0.05
+		if diff := got - want; diff < -tolerance || diff > tolerance {
+			t.Errorf("p=%.2f: estimate %.2f too far from sorted baseline %.2f (tolerance %.2f)", p, got, want, tolerance)
+		}
+	}
+}
+
+func TestP2EstimatorFewerThanFiveSamples(t *testing.T) {
+	e := newP2Estimator(0.5)
+	if v := e.Value(); v != 0 {
+		t.Fatalf("empty estimator: got %v, want 0", v)
+	}
+
+	e.Add(3)
+	e.Add(1)
+	e.Add(2)
+	if v := e.Value(); v != 2 {
+		t.Fatalf("median of [3,1,2]: got %v, want 2", v)
+	}
+}
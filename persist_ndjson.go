@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ndjsonStore appends each Sample as one JSON line to an append-only
+// file, rotating by size and/or age so a long-running process doesn't
+// grow a single file without bound.
+type ndjsonStore struct {
+	mutex       sync.Mutex
+	path        string
+	rotateSize  int64
+	rotateEvery time.Duration
+	file        *os.File
+	writer      *bufio.Writer
+	size        int64
+	opened      time.Time
+}
+
+func newNDJSONStore(c PersistenceConfig) (*ndjsonStore, error) {
+	path := c.Path
+	if path == "" {
+		path = "latency_log.ndjson"
+	}
+	s := &ndjsonStore{path: path, rotateSize: c.RotateSize, rotateEvery: c.RotateInterval}
+	if err := s.openCurrent(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ndjsonStore) openCurrent() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.writer = bufio.NewWriter(f)
+	s.size = info.Size()
+	s.opened = time.Now()
+	return nil
+}
+
+func (s *ndjsonStore) Append(sample Sample) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.rotateIfNeeded(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(sample)
+	if err != nil {
+		return err
+	}
+	n, err := s.writer.Write(append(b, '\n'))
+	s.size += int64(n)
+	return err
+}
+
+func (s *ndjsonStore) rotateIfNeeded() error {
+	due := (s.rotateSize > 0 && s.size >= s.rotateSize) ||
+		(s.rotateEvery > 0 && time.Since(s.opened) >= s.rotateEvery)
+	if !due {
+		return nil
+	}
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	s.file.Close()
+	rotated := fmt.Sprintf("%s.%d", s.path, time.Now().UnixNano())
+	if err := os.Rename(s.path, rotated); err != nil {
+		return err
+	}
+	return s.openCurrent()
+}
+
+// Backfill scans the current file for samples matching host, used to
+// reseed a HostStats' ring buffer on startup. Rotated-out files are not
+// scanned, matching the rotation's intent of bounding what's retained
+// in the hot file.
+func (s *ndjsonStore) Backfill(host string, since time.Time) ([]Sample, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.writer.Flush(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []Sample
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var sample Sample
+		if err := dec.Decode(&sample); err != nil {
+			return out, err
+		}
+		if sample.Host != host {
+			continue
+		}
+		if !since.IsZero() && sample.Timestamp.Before(since) {
+			continue
+		}
+		out = append(out, sample)
+	}
+	return out, nil
+}
+
+func (s *ndjsonStore) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.writer.Flush()
+}
+
+func (s *ndjsonStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// webhookStore wraps another Store and additionally forwards every
+// appended sample to an HTTP endpoint as a best-effort side channel: a
+// failed push is logged but never fails the Append, so a down endpoint
+// can't block local persistence.
+//
+// This posts a JSON-encoded Sample with Content-Type: application/json.
+// It is NOT the Prometheus remote_write wire protocol (snappy-compressed
+// protobuf WriteRequest over Content-Type: application/x-protobuf), so it
+// cannot be pointed at Prometheus/Cortex/Mimir/Thanos/VictoriaMetrics
+// /api/v1/write endpoints and expect them to accept it. Point it at a
+// webhook or collector that understands this JSON shape instead.
+type webhookStore struct {
+	inner  Store
+	url    string
+	client *http.Client
+}
+
+func newWebhookStore(inner Store, c WebhookConfig) *webhookStore {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &webhookStore{
+		inner:  inner,
+		url:    c.URL,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *webhookStore) Append(sample Sample) error {
+	if err := s.inner.Append(sample); err != nil {
+		return err
+	}
+	s.push(sample)
+	return nil
+}
+
+func (s *webhookStore) push(sample Sample) {
+	body, err := json.Marshal(sample)
+	if err != nil {
+		logger.Error("webhook encode failed", "host", sample.Host, "err", err)
+		return
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("webhook push failed", "url", s.url, "host", sample.Host, "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Warn("webhook push rejected", "url", s.url, "host", sample.Host, "status", resp.StatusCode)
+	}
+}
+
+func (s *webhookStore) Backfill(host string, since time.Time) ([]Sample, error) {
+	return s.inner.Backfill(host, since)
+}
+
+func (s *webhookStore) Flush() error { return s.inner.Flush() }
+
+func (s *webhookStore) Close() error { return s.inner.Close() }
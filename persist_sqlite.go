@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteStore persists samples into a SQLite database indexed on
+// (host, ts), so Backfill's range query stays fast even with a large
+// history. modernc.org/sqlite is pure Go, so this backend doesn't
+// require cgo or a system libsqlite3.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func newSQLiteStore(c PersistenceConfig) (*sqliteStore, error) {
+	path := c.Path
+	if path == "" {
+		path = "latency_log.db"
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	const schema = `
+CREATE TABLE IF NOT EXISTS samples (
+	host   TEXT    NOT NULL,
+	proto  TEXT    NOT NULL,
+	ts     INTEGER NOT NULL,
+	rtt_ns INTEGER NOT NULL,
+	lost   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_samples_host_ts ON samples(host, ts);
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Append(sample Sample) error {
+	lost := 0
+	if sample.Lost {
+		lost = 1
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO samples (host, proto, ts, rtt_ns, lost) VALUES (?, ?, ?, ?, ?)`,
+		sample.Host, sample.Proto, sample.Timestamp.UnixNano(), int64(sample.RTT), lost,
+	)
+	return err
+}
+
+func (s *sqliteStore) Backfill(host string, since time.Time) ([]Sample, error) {
+	sinceNS := int64(0)
+	if !since.IsZero() {
+		sinceNS = since.UnixNano()
+	}
+	rows, err := s.db.Query(
+		`SELECT proto, ts, rtt_ns, lost FROM samples WHERE host = ? AND ts >= ? ORDER BY ts ASC`,
+		host, sinceNS,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Sample
+	for rows.Next() {
+		var ts, rttNS int64
+		var lost int
+		sample := Sample{Host: host}
+		if err := rows.Scan(&sample.Proto, &ts, &rttNS, &lost); err != nil {
+			return out, err
+		}
+		sample.Timestamp = time.Unix(0, ts)
+		sample.RTT = time.Duration(rttNS)
+		sample.Lost = lost != 0
+		out = append(out, sample)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqliteStore) Flush() error { return nil }
+
+func (s *sqliteStore) Close() error { return s.db.Close() }
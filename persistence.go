@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultFlushInterval = 5 * time.Second
+
+// PersistenceConfig selects and configures the time-series persistence
+// backend: "ndjson", "sqlite", or "none"/"" for in-memory only.
+type PersistenceConfig struct {
+	Backend        string         `yaml:"backend"`
+	Path           string         `yaml:"path"`
+	RotateSize     int64          `yaml:"rotate_size_bytes"`
+	RotateInterval time.Duration  `yaml:"rotate_interval"`
+	FlushInterval  time.Duration  `yaml:"flush_interval"`
+	Webhook        *WebhookConfig `yaml:"webhook"`
+}
+
+// WebhookConfig points at an HTTP endpoint every persisted sample is
+// additionally forwarded to as a JSON POST (not Prometheus remote_write).
+type WebhookConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Store is a pluggable time-series backend for latency samples.
+type Store interface {
+	Append(Sample) error
+	Backfill(host string, since time.Time) ([]Sample, error)
+	Flush() error
+	Close() error
+}
+
+// newStore builds the Store described by c, wrapped in a bufferedStore.
+func newStore(c PersistenceConfig) (Store, error) {
+	var inner Store
+
+	switch c.Backend {
+	case "", "none":
+		inner = noopStore{}
+	case "ndjson":
+		s, err := newNDJSONStore(c)
+		if err != nil {
+			logger.Error("ndjson store init failed, persisting nowhere", "path", c.Path, "err", err)
+			inner = noopStore{}
+		} else {
+			inner = s
+		}
+	case "sqlite":
+		s, err := newSQLiteStore(c)
+		if err != nil {
+			logger.Error("sqlite store init failed, persisting nowhere", "path", c.Path, "err", err)
+			inner = noopStore{}
+		} else {
+			inner = s
+		}
+	default:
+		return nil, fmt.Errorf("persistence: unknown backend %q", c.Backend)
+	}
+
+	if c.Webhook != nil && c.Webhook.URL != "" {
+		inner = newWebhookStore(inner, *c.Webhook)
+	}
+
+	flushEvery := c.FlushInterval
+	if flushEvery == 0 {
+		flushEvery = defaultFlushInterval
+	}
+	return newBufferedStore(inner, flushEvery), nil
+}
+
+// noopStore discards every sample; it backs backend "none" and is also
+// the fallback when a configured backend fails to initialize.
+type noopStore struct{}
+
+func (noopStore) Append(Sample) error                          { return nil }
+func (noopStore) Backfill(string, time.Time) ([]Sample, error) { return nil, nil }
+func (noopStore) Flush() error                                 { return nil }
+func (noopStore) Close() error                                 { return nil }
+
+// bufferedStore queues Append calls and drains them into inner from a
+// background goroutine on a ticker, so a slow backend never blocks a probe loop.
+type bufferedStore struct {
+	inner   Store
+	mutex   sync.Mutex
+	queue   []Sample
+	ticker  *time.Ticker
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newBufferedStore(inner Store, flushEvery time.Duration) *bufferedStore {
+	b := &bufferedStore{
+		inner:   inner,
+		ticker:  time.NewTicker(flushEvery),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *bufferedStore) Append(s Sample) error {
+	b.mutex.Lock()
+	b.queue = append(b.queue, s)
+	b.mutex.Unlock()
+	return nil
+}
+
+func (b *bufferedStore) run() {
+	defer close(b.stopped)
+	for {
+		select {
+		case <-b.ticker.C:
+			b.drain()
+		case <-b.done:
+			b.drain()
+			return
+		}
+	}
+}
+
+func (b *bufferedStore) drain() {
+	b.mutex.Lock()
+	pending := b.queue
+	b.queue = nil
+	b.mutex.Unlock()
+
+	for _, s := range pending {
+		if err := b.inner.Append(s); err != nil {
+			logger.Error("persistence write failed", "host", s.Host, "err", err)
+		}
+	}
+	if err := b.inner.Flush(); err != nil {
+		logger.Error("persistence flush failed", "err", err)
+	}
+}
+
+func (b *bufferedStore) Backfill(host string, since time.Time) ([]Sample, error) {
+	return b.inner.Backfill(host, since)
+}
+
+func (b *bufferedStore) Flush() error {
+	b.drain()
+	return nil
+}
+
+// Close flushes the drain goroutine one last time before closing inner.
+func (b *bufferedStore) Close() error {
+	close(b.done)
+	<-b.stopped
+	b.ticker.Stop()
+	return b.inner.Close()
+}
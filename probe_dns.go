@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// dnsProber measures query RTT for an A/AAAA/CNAME lookup, optionally
+// against a specific resolver rather than the system default.
+type dnsProber struct {
+	host       string
+	recordType string
+	resolver   *net.Resolver
+}
+
+func newDNSProber(hc HostConfig) *dnsProber {
+	recordType := hc.RecordType
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	resolver := net.DefaultResolver
+	if hc.Resolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: cfg.Timeout}
+				return d.DialContext(ctx, network, hc.Resolver)
+			},
+		}
+	}
+
+	return &dnsProber{host: hc.Addr, recordType: recordType, resolver: resolver}
+}
+
+func (p *dnsProber) Probe(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch p.recordType {
+	case "AAAA":
+		_, err = p.resolver.LookupIP(ctx, "ip6", p.host)
+	case "CNAME":
+		_, err = p.resolver.LookupCNAME(ctx, p.host)
+	default:
+		_, err = p.resolver.LookupIP(ctx, "ip4", p.host)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
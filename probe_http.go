@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// httpProber measures either time-to-first-byte or full-body latency
+// (per hc.Measure) for a GET request against the configured host and
+// path, and validates the response status against an allowlist (any 2xx
+// if none is configured).
+type httpProber struct {
+	url            string
+	fullBody       bool
+	expectedStatus []int
+	client         *http.Client
+}
+
+func newHTTPProber(hc HostConfig) *httpProber {
+	scheme := hc.Proto
+	path := hc.Path
+	if path == "" {
+		path = "/"
+	}
+	return &httpProber{
+		url:            scheme + "://" + hc.Addr + path,
+		fullBody:       hc.Measure == "full_body",
+		expectedStatus: hc.ExpectedStatus,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{ServerName: hc.SNI},
+			},
+		},
+	}
+}
+
+func (p *httpProber) Probe(ctx context.Context) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var start, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start = time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp.StatusCode, p.expectedStatus); err != nil {
+		return 0, err
+	}
+
+	if p.fullBody {
+		if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	}
+
+	if firstByte.IsZero() {
+		firstByte = time.Now()
+	}
+	return firstByte.Sub(start), nil
+}
+
+func checkStatus(code int, allowlist []int) error {
+	if len(allowlist) == 0 {
+		if code < 200 || code >= 300 {
+			return &unexpectedStatusError{code: code}
+		}
+		return nil
+	}
+	for _, want := range allowlist {
+		if code == want {
+			return nil
+		}
+	}
+	return &unexpectedStatusError{code: code}
+}
+
+type unexpectedStatusError struct{ code int }
+
+func (e *unexpectedStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d (%s)", e.code, http.StatusText(e.code))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// icmpProber sends ICMP echo requests over a single long-lived raw
+// socket, incrementing the sequence number on each Probe call.
+type icmpProber struct {
+	ip   string
+	conn *icmp.PacketConn
+	seq  int
+}
+
+func newICMPProber(host string) (*icmpProber, error) {
+	ip, err := resolveHost(host)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, err
+	}
+	return &icmpProber{ip: ip, conn: conn}, nil
+}
+
+func (p *icmpProber) Probe(ctx context.Context) (time.Duration, error) {
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Code: 0,
+		Body: &icmp.Echo{
+			ID:   os.Getpid() & 0xffff,
+			Seq:  p.seq,
+			Data: []byte("PING"),
+		},
+	}
+	p.seq++
+
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := time.Now().Add(cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	p.conn.SetDeadline(deadline)
+
+	start := time.Now()
+	if _, err := p.conn.WriteTo(b, &net.IPAddr{IP: net.ParseIP(p.ip)}); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, 1500)
+	if _, _, err := p.conn.ReadFrom(resp); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+func (p *icmpProber) Close() error { return p.conn.Close() }
+
+func resolveHost(host string) (string, error) {
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return "", err
+	}
+	return ips[0].String(), nil
+}
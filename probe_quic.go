@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// quicProber measures QUIC handshake time: dial plus TLS handshake
+// completion, then the connection is torn down immediately.
+type quicProber struct {
+	addr   string
+	tlsCfg *tls.Config
+}
+
+func newQUICProber(hc HostConfig) *quicProber {
+	sni := hc.SNI
+	alpn := hc.ALPN
+	if len(alpn) == 0 {
+		alpn = []string{"h3"} // most real-world QUIC endpoints only accept HTTP/3's ALPN
+	}
+	return &quicProber{
+		addr: hc.Addr,
+		tlsCfg: &tls.Config{
+			ServerName: sni,
+			NextProtos: alpn,
+		},
+	}
+}
+
+func (p *quicProber) Probe(ctx context.Context) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := quic.DialAddr(ctx, p.addr, p.tlsCfg, nil)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.CloseWithError(0, "")
+	return rtt, nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// tcpProber measures TCP connect time to addr.
+type tcpProber struct {
+	addr string
+}
+
+func newTCPProber(addr string) *tcpProber {
+	return &tcpProber{addr: addr}
+}
+
+func (p *tcpProber) Probe(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", p.addr, cfg.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return time.Since(start), nil
+}
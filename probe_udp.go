@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// udpProber sends a payload and waits for either an echo of that
+// payload or a user-specified expected response.
+type udpProber struct {
+	addr    string
+	payload []byte
+	expect  []byte
+}
+
+func newUDPProber(hc HostConfig) *udpProber {
+	payload := hc.Payload
+	if payload == "" {
+		payload = "PING"
+	}
+	var expect []byte
+	if hc.Expect != "" {
+		expect = []byte(hc.Expect)
+	}
+	return &udpProber{addr: hc.Addr, payload: []byte(payload), expect: expect}
+}
+
+func (p *udpProber) Probe(ctx context.Context) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", p.addr, cfg.Timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := conn.Write(p.payload); err != nil {
+		return 0, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(cfg.Timeout))
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+
+	want := p.expect
+	if want == nil {
+		want = p.payload
+	}
+	if !bytes.Equal(buf[:n], want) {
+		return 0, errors.New("udp response did not match expected payload")
+	}
+	return rtt, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HostConfig describes one probe target. It unmarshals from either a
+// bare "host:port" string (defaulting to a TCP probe, or ICMP when
+// use_icmp is set, for backwards compatibility with existing configs)
+// or a mapping that selects a
+// protocol and its options.
+type HostConfig struct {
+	Addr           string   `yaml:"addr"`
+	Proto          string   `yaml:"proto"` // http|https|dns|udp|quic|icmp|tcp
+	Path           string   `yaml:"path,omitempty"`
+	Measure        string   `yaml:"measure,omitempty"` // http(s): "ttfb" (default) or "full_body"
+	ExpectedStatus []int    `yaml:"expected_status,omitempty"`
+	Resolver       string   `yaml:"resolver,omitempty"`
+	RecordType     string   `yaml:"record_type,omitempty"` // dns: A|AAAA|CNAME
+	SNI            string   `yaml:"sni,omitempty"`
+	ALPN           []string `yaml:"alpn,omitempty"`
+	Payload        string   `yaml:"payload,omitempty"` // udp
+	Expect         string   `yaml:"expect,omitempty"`  // udp
+}
+
+func (h *HostConfig) UnmarshalYAML(unmarshal func(any) error) error {
+	var addr string
+	if err := unmarshal(&addr); err == nil {
+		h.Addr = addr
+		return nil
+	}
+
+	type plain HostConfig
+	var p plain
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*h = HostConfig(p)
+	return nil
+}
+
+// applyLegacyDefault fills in Proto for hosts that didn't specify one
+// (the bare "host:port" string form, or a mapping that omitted proto),
+// honoring the older top-level use_icmp switch.
+func (h *HostConfig) applyLegacyDefault(useICMP bool) {
+	if h.Proto != "" {
+		return
+	}
+	if useICMP {
+		h.Proto = "icmp"
+	} else {
+		h.Proto = "tcp"
+	}
+}
+
+// Prober performs a single round trip against a target and reports how
+// long it took. Implementations own whatever connection state they
+// need between calls (e.g. a persistent ICMP socket).
+type Prober interface {
+	Probe(ctx context.Context) (time.Duration, error)
+}
+
+// newProber builds the Prober for hc.Proto, opening any long-lived
+// resources (sockets, HTTP clients) it needs up front.
+func newProber(hc HostConfig) (Prober, error) {
+	switch hc.Proto {
+	case "icmp":
+		return newICMPProber(hc.Addr)
+	case "tcp", "":
+		return newTCPProber(hc.Addr), nil
+	case "http", "https":
+		return newHTTPProber(hc), nil
+	case "dns":
+		return newDNSProber(hc), nil
+	case "udp":
+		return newUDPProber(hc), nil
+	case "quic":
+		return newQUICProber(hc), nil
+	default:
+		return nil, fmt.Errorf("unknown proto %q for host %q", hc.Proto, hc.Addr)
+	}
+}
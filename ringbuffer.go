@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rttSample pairs an RTT with the time it was observed.
+type rttSample struct {
+	Timestamp time.Time
+	RTT       time.Duration
+}
+
+// RTTRingBuffer holds the last `capacity` RTT samples for a host. It is
+// append-only from the prober's point of view: writers serialize on mu,
+// but each successful append publishes a fresh immutable snapshot via an
+// atomic pointer, so readers (the UI, the gRPC/REST gateway, the
+// exporter) never block on a lock while walking the window.
+type RTTRingBuffer struct {
+	mu       sync.Mutex
+	buf      []rttSample
+	next     int
+	filled   bool
+	snapshot atomic.Pointer[[]rttSample]
+}
+
+// NewRTTRingBuffer creates a ring buffer holding at most capacity
+// samples.
+func NewRTTRingBuffer(capacity int) *RTTRingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &RTTRingBuffer{buf: make([]rttSample, capacity)}
+	empty := []rttSample{}
+	r.snapshot.Store(&empty)
+	return r
+}
+
+// Append records a new sample, evicting the oldest one once the buffer
+// is full.
+func (r *RTTRingBuffer) Append(ts time.Time, rtt time.Duration) {
+	r.mu.Lock()
+	r.buf[r.next] = rttSample{Timestamp: ts, RTT: rtt}
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.filled = true
+	}
+
+	ordered := make([]rttSample, 0, len(r.buf))
+	if r.filled {
+		ordered = append(ordered, r.buf[r.next:]...)
+	}
+	ordered = append(ordered, r.buf[:r.next]...)
+	r.snapshot.Store(&ordered)
+	r.mu.Unlock()
+}
+
+// Snapshot returns the current window, oldest sample first. The
+// returned slice is immutable and safe to read without further
+// synchronization.
+func (r *RTTRingBuffer) Snapshot() []rttSample {
+	return *r.snapshot.Load()
+}
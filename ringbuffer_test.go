@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRTTRingBufferWraparoundOrdering(t *testing.T) {
+	r := NewRTTRingBuffer(3)
+	base := time.Unix(0, 0)
+
+	for i := 0; i < 5; i++ {
+		r.Append(base.Add(time.Duration(i)*time.Second), time.Duration(i)*time.Millisecond)
+	}
+
+	got := r.Snapshot()
+	if len(got) != 3 {
+		t.Fatalf("snapshot length: got %d, want 3", len(got))
+	}
+
+	want := []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}
+	for i, sample := range got {
+		if sample.RTT != want[i] {
+			t.Errorf("index %d: got RTT %v, want %v", i, sample.RTT, want[i])
+		}
+	}
+	if !got[0].Timestamp.Before(got[1].Timestamp) || !got[1].Timestamp.Before(got[2].Timestamp) {
+		t.Errorf("snapshot not oldest-first: %+v", got)
+	}
+}
+
+func TestRTTRingBufferBeforeFull(t *testing.T) {
+	r := NewRTTRingBuffer(5)
+	base := time.Unix(0, 0)
+	r.Append(base, 1*time.Millisecond)
+	r.Append(base.Add(time.Second), 2*time.Millisecond)
+
+	got := r.Snapshot()
+	if len(got) != 2 {
+		t.Fatalf("snapshot length: got %d, want 2", len(got))
+	}
+	if got[0].RTT != 1*time.Millisecond || got[1].RTT != 2*time.Millisecond {
+		t.Errorf("unexpected order: %+v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RollingSnapshot is a point-in-time read of a RollingStats engine.
+type RollingSnapshot struct {
+	Count     int64         `json:"count"`
+	Min       time.Duration `json:"min_ns"`
+	Mean      time.Duration `json:"mean_ns"`
+	Max       time.Duration `json:"max_ns"`
+	StdDev    time.Duration `json:"stddev_ns"`
+	P50       time.Duration `json:"p50_ns"`
+	P90       time.Duration `json:"p90_ns"`
+	P95       time.Duration `json:"p95_ns"`
+	P99       time.Duration `json:"p99_ns"`
+	JitterMS  float64       `json:"jitter_ms"`
+	EWMARTTMS float64       `json:"ewma_rtt_ms"`
+	EWMALoss  float64       `json:"ewma_loss_pct"`
+	MOS       float64       `json:"mos"`
+}
+
+// RollingStats continuously maintains min/mean/max/stddev, P² quantiles,
+// RFC 3550 jitter, and EWMAs of RTT/loss for a host, without rescanning
+// the sample history.
+type RollingStats struct {
+	mu    sync.Mutex
+	alpha float64
+
+	count    int64
+	mean, m2 float64 // Welford's algorithm, nanoseconds
+	min, max time.Duration
+
+	p50, p90, p95, p99 *p2Estimator
+
+	haveLastRTT bool
+	lastRTTMS   float64
+	jitterMS    float64
+
+	haveEWMARTT  bool
+	ewmaRTTMS    float64
+	haveEWMALoss bool
+	ewmaLoss     float64
+}
+
+// NewRollingStats creates a stats engine with the given EWMA smoothing factor.
+func NewRollingStats(alpha float64) *RollingStats {
+	return &RollingStats{
+		alpha: alpha,
+		p50:   newP2Estimator(0.50),
+		p90:   newP2Estimator(0.90),
+		p95:   newP2Estimator(0.95),
+		p99:   newP2Estimator(0.99),
+	}
+}
+
+// Record folds one probe outcome into the running statistics. rtt is
+// ignored when success is false.
+func (r *RollingStats) Record(success bool, rtt time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lossEvent := 0.0
+	if !success {
+		lossEvent = 100.0
+	}
+	if r.haveEWMALoss {
+		r.ewmaLoss = r.alpha*lossEvent + (1-r.alpha)*r.ewmaLoss
+	} else {
+		r.ewmaLoss = lossEvent
+		r.haveEWMALoss = true
+	}
+	if !success {
+		return
+	}
+
+	ns := float64(rtt.Nanoseconds())
+	r.count++
+	delta := ns - r.mean
+	r.mean += delta / float64(r.count)
+	r.m2 += delta * (ns - r.mean)
+	if r.count == 1 || rtt < r.min {
+		r.min = rtt
+	}
+	if r.count == 1 || rtt > r.max {
+		r.max = rtt
+	}
+
+	r.p50.Add(ns)
+	r.p90.Add(ns)
+	r.p95.Add(ns)
+	r.p99.Add(ns)
+
+	rttMS := float64(rtt.Microseconds()) / 1000
+	if r.haveLastRTT {
+		d := rttMS - r.lastRTTMS
+		if d < 0 {
+			d = -d
+		}
+		r.jitterMS += (d - r.jitterMS) / 16
+	}
+	r.lastRTTMS = rttMS
+	r.haveLastRTT = true
+
+	if r.haveEWMARTT {
+		r.ewmaRTTMS = r.alpha*rttMS + (1-r.alpha)*r.ewmaRTTMS
+	} else {
+		r.ewmaRTTMS = rttMS
+		r.haveEWMARTT = true
+	}
+}
+
+// Snapshot returns the current statistics.
+func (r *RollingStats) Snapshot() RollingSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stddev float64
+	if r.count > 1 {
+		stddev = math.Sqrt(r.m2 / float64(r.count-1))
+	}
+
+	return RollingSnapshot{
+		Count:     r.count,
+		Min:       r.min,
+		Mean:      time.Duration(r.mean),
+		Max:       r.max,
+		StdDev:    time.Duration(stddev),
+		P50:       time.Duration(r.p50.Value()),
+		P90:       time.Duration(r.p90.Value()),
+		P95:       time.Duration(r.p95.Value()),
+		P99:       time.Duration(r.p99.Value()),
+		JitterMS:  r.jitterMS,
+		EWMARTTMS: r.ewmaRTTMS,
+		EWMALoss:  r.ewmaLoss,
+		MOS:       estimateMOS(r.ewmaRTTMS, r.jitterMS, r.ewmaLoss),
+	}
+}
+
+// estimateMOS derives a Mean Opinion Score (1..4.5) via the simplified
+// ITU-T G.107 E-model.
+func estimateMOS(rttMS, jitterMS, lossPct float64) float64 {
+	effectiveLatency := rttMS + jitterMS*2 + 10
+
+	var id float64
+	if effectiveLatency < 160 {
+		id = effectiveLatency / 40
+	} else {
+		id = (effectiveLatency - 120) / 10
+	}
+	ie := lossPct * 2.5
+
+	r := 93.2 - id - ie
+	if r < 0 {
+		r = 0
+	}
+
+	mos := 1 + 0.035*r + 0.000007*r*(r-60)*(100-r)
+	switch {
+	case mos < 1:
+		mos = 1
+	case mos > 4.5:
+		mos = 4.5
+	}
+	return mos
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestEstimateMOSBounds(t *testing.T) {
+	cases := []struct {
+		name                     string
+		rttMS, jitterMS, lossPct float64
+	}{
+		{"ideal", 0, 0, 0},
+		{"typical broadband", 30, 5, 0.1},
+		{"bad", 500, 200, 50},
+		{"extreme loss", 10, 0, 100},
+	}
+	for _, c := range cases {
+		mos := estimateMOS(c.rttMS, c.jitterMS, c.lossPct)
+		if mos < 1 || mos > 4.5 {
+			t.Errorf("%s: MOS %v out of range [1, 4.5]", c.name, mos)
+		}
+	}
+}
+
+func TestEstimateMOSMonotonicInLoss(t *testing.T) {
+	low := estimateMOS(30, 5, 0)
+	high := estimateMOS(30, 5, 20)
+	if !(low > high) {
+		t.Errorf("expected MOS to drop as loss increases: low=%v high=%v", low, high)
+	}
+}
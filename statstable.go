@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+func statsTableHeader() []string {
+	return []string{"Host", "Min", "Avg", "Max", "StdDev", "P50", "P90", "P95", "P99", "Jitter", "EWMA RTT", "EWMA Loss", "MOS"}
+}
+
+// statsTableRow renders one RollingStats snapshot as a row for the
+// termui stats table shown alongside the latency plot.
+func statsTableRow(s *HostStats) []string {
+	snap := s.rolling.Snapshot()
+	return []string{
+		s.Host,
+		fmtMS(snap.Min),
+		fmtMS(snap.Mean),
+		fmtMS(snap.Max),
+		fmtMS(snap.StdDev),
+		fmtMS(snap.P50),
+		fmtMS(snap.P90),
+		fmtMS(snap.P95),
+		fmtMS(snap.P99),
+		fmt.Sprintf("%.1fms", snap.JitterMS),
+		fmt.Sprintf("%.1fms", snap.EWMARTTMS),
+		fmt.Sprintf("%.1f%%", snap.EWMALoss),
+		fmt.Sprintf("%.2f", snap.MOS),
+	}
+}
+
+func fmtMS(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
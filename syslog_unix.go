@@ -0,0 +1,20 @@
+//go:build !windows
+
+package main
+
+import (
+	"io"
+	"log/syslog"
+)
+
+func dialSyslog(c SyslogConfig) (io.WriteCloser, error) {
+	tag := c.Tag
+	if tag == "" {
+		tag = "latency-visualizer"
+	}
+	w, err := syslog.Dial(c.Network, c.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
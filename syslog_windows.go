@@ -0,0 +1,12 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"io"
+)
+
+func dialSyslog(c SyslogConfig) (io.WriteCloser, error) {
+	return nil, errors.New("syslog hook is not supported on windows")
+}
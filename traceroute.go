@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	maxHops        = 30
+	tracerouteBase = 33434 // classic traceroute's base UDP destination port
+)
+
+// HopStats aggregates round-trip measurements for a single hop along
+// the path to a host, mtr-style.
+type HopStats struct {
+	TTL      int
+	Addr     string
+	Hostname string
+
+	Sent     int
+	Received int
+	Last     time.Duration
+	Best     time.Duration
+	Worst    time.Duration
+
+	sumRTT        time.Duration
+	sumSqRTT      float64 // nanoseconds^2, for stddev
+	resolving     bool    // a reverse-DNS lookup for this hop is in flight
+	resolveFailed bool    // the one lookup we tried came back empty/errored
+	mutex         sync.Mutex
+}
+
+func (h *HopStats) record(rtt time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.Received++
+	h.Last = rtt
+	if h.Best == 0 || rtt < h.Best {
+		h.Best = rtt
+	}
+	if rtt > h.Worst {
+		h.Worst = rtt
+	}
+	h.sumRTT += rtt
+	ns := float64(rtt.Nanoseconds())
+	h.sumSqRTT += ns * ns
+}
+
+func (h *HopStats) lossPercent() float64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.Sent == 0 {
+		return 0
+	}
+	return float64(h.Sent-h.Received) / float64(h.Sent) * 100
+}
+
+func (h *HopStats) avg() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.Received == 0 {
+		return 0
+	}
+	return h.sumRTT / time.Duration(h.Received)
+}
+
+func (h *HopStats) stddev() time.Duration {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.Received < 2 {
+		return 0
+	}
+	mean := float64(h.sumRTT.Nanoseconds()) / float64(h.Received)
+	variance := h.sumSqRTT/float64(h.Received) - mean*mean
+	if variance < 0 {
+		variance = 0
+	}
+	return time.Duration(sqrt(variance))
+}
+
+func sqrt(x float64) float64 {
+	return math.Sqrt(x)
+}
+
+// runTraceroute sends one traceroute/MTR sweep per tick, folding results
+// into stats.Hops, until `run` is cleared.
+func runTraceroute(hc HostConfig, stats *HostStats) {
+	ipv6Target := isIPv6(hc.Addr)
+
+	for run {
+		hops, err := traceOnce(hc, ipv6Target)
+		if err != nil {
+			logger.Warn("traceroute failed", "host", hc.Addr, "err", err)
+			time.Sleep(cfg.Interval)
+			continue
+		}
+
+		stats.recordHops(hops)
+		time.Sleep(cfg.Interval)
+	}
+}
+
+type hopResult struct {
+	ttl  int
+	addr string
+	rtt  time.Duration
+	done bool // true once the destination itself responded
+}
+
+// traceOnce performs a single TTL-incrementing sweep and returns one
+// hopResult per TTL tried, in order, with addr left empty (mtr's `*`
+// row) for a hop that didn't answer within cfg.Timeout.
+func traceOnce(hc HostConfig, ipv6Target bool) ([]hopResult, error) {
+	host, _, err := net.SplitHostPort(hc.Addr)
+	if err != nil {
+		host = hc.Addr
+	}
+	ip, err := resolveHost(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []hopResult
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		var res hopResult
+		var perr error
+		if hc.Proto == "udp" {
+			res, perr = probeHopUDP(ip, ttl, ipv6Target)
+		} else {
+			res, perr = probeHopICMP(ip, ttl, ipv6Target)
+		}
+		if perr != nil {
+			// Hop didn't answer in time: still counts as a sent probe for
+			// that TTL (addr left empty marks it unanswered), so loss% at
+			// this hop reflects the timeout instead of vanishing.
+			results = append(results, hopResult{ttl: ttl})
+			continue
+		}
+		results = append(results, res)
+		if res.done {
+			break
+		}
+	}
+	return results, nil
+}
+
+func probeHopICMP(dst string, ttl int, ipv6Target bool) (hopResult, error) {
+	network, proto := "ip4:icmp", "ip4"
+	if ipv6Target {
+		network, proto = "ip6:ipv6-icmp", "ip6"
+	}
+
+	conn, err := icmp.ListenPacket(network, zeroAddr(ipv6Target))
+	if err != nil {
+		return hopResult{}, err
+	}
+	defer conn.Close()
+
+	id := os.Getpid() & 0xffff
+	msgType := icmp.Type(ipv4.ICMPTypeEcho)
+	if ipv6Target {
+		msgType = ipv6.ICMPTypeEchoRequest
+	}
+	msg := icmp.Message{
+		Type: msgType,
+		Code: 0,
+		Body: &icmp.Echo{ID: id, Seq: ttl, Data: []byte("mtr")},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return hopResult{}, err
+	}
+
+	if ipv6Target {
+		conn.IPv6PacketConn().SetHopLimit(ttl)
+	} else {
+		conn.IPv4PacketConn().SetTTL(ttl)
+	}
+	conn.SetDeadline(time.Now().Add(cfg.Timeout))
+
+	dstAddr, err := net.ResolveIPAddr(proto, dst)
+	if err != nil {
+		return hopResult{}, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(b, dstAddr); err != nil {
+		return hopResult{}, err
+	}
+
+	resp := make([]byte, 1500)
+	n, peer, err := conn.ReadFrom(resp)
+	if err != nil {
+		return hopResult{}, err
+	}
+	rtt := time.Since(start)
+
+	protoNum := 1
+	if ipv6Target {
+		protoNum = 58
+	}
+	reply, err := icmp.ParseMessage(protoNum, resp[:n])
+	if err != nil {
+		return hopResult{}, err
+	}
+
+	done := reply.Type == ipv4.ICMPTypeEchoReply || reply.Type == ipv6.ICMPTypeEchoReply
+	return hopResult{ttl: ttl, addr: peer.String(), rtt: rtt, done: done}, nil
+}
+
+// probeHopUDP implements classic Unix traceroute: send a UDP datagram
+// nobody is listening on, and read the ICMP time-exceeded response.
+func probeHopUDP(dst string, ttl int, ipv6Target bool) (hopResult, error) {
+	network := "udp4"
+	icmpNetwork, icmpProto := "ip4:icmp", 1
+	if ipv6Target {
+		network = "udp6"
+		icmpNetwork, icmpProto = "ip6:ipv6-icmp", 58
+	}
+
+	icmpConn, err := icmp.ListenPacket(icmpNetwork, zeroAddr(ipv6Target))
+	if err != nil {
+		return hopResult{}, err
+	}
+	defer icmpConn.Close()
+	icmpConn.SetDeadline(time.Now().Add(cfg.Timeout))
+
+	udpConn, err := net.Dial(network, fmt.Sprintf("%s:%d", dst, tracerouteBase+ttl))
+	if err != nil {
+		return hopResult{}, err
+	}
+	defer udpConn.Close()
+
+	if ipv6Target {
+		ipv6.NewPacketConn(udpConn.(net.PacketConn)).SetHopLimit(ttl)
+	} else {
+		ipv4.NewPacketConn(udpConn.(net.PacketConn)).SetTTL(ttl)
+	}
+
+	start := time.Now()
+	if _, err := udpConn.Write([]byte("mtr")); err != nil {
+		return hopResult{}, err
+	}
+
+	resp := make([]byte, 1500)
+	n, peer, err := icmpConn.ReadFrom(resp)
+	if err != nil {
+		return hopResult{}, err
+	}
+	rtt := time.Since(start)
+
+	reply, err := icmp.ParseMessage(icmpProto, resp[:n])
+	if err != nil {
+		return hopResult{}, err
+	}
+
+	done := reply.Type == ipv4.ICMPTypeDestinationUnreachable || reply.Type == ipv6.ICMPTypeDestinationUnreachable
+	return hopResult{ttl: ttl, addr: peer.String(), rtt: rtt, done: done}, nil
+}
+
+func zeroAddr(ipv6Target bool) string {
+	if ipv6Target {
+		return "::"
+	}
+	return "0.0.0.0"
+}
+
+func isIPv6(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() == nil
+}
+
+// recordHops folds one traceroute sweep into the host's per-hop history
+// and detects route flaps by hashing the ordered hop address sequence.
+func (s *HostStats) recordHops(results []hopResult) {
+	s.hopsMutex.Lock()
+	defer s.hopsMutex.Unlock()
+
+	h := fnv.New64a()
+	for _, res := range results {
+		for len(s.Hops) < res.ttl {
+			s.Hops = append(s.Hops, &HopStats{TTL: len(s.Hops) + 1})
+		}
+		hop := s.Hops[res.ttl-1]
+		hop.mutex.Lock()
+		hop.Sent++
+		if res.addr != "" {
+			hop.Addr = res.addr
+		}
+		shouldResolve := res.addr != "" && hop.Hostname == "" && !hop.resolving && !hop.resolveFailed
+		if shouldResolve {
+			hop.resolving = true
+		}
+		hop.mutex.Unlock()
+		if res.addr != "" {
+			hop.record(res.rtt)
+		}
+		if shouldResolve {
+			go resolveHopHostname(hop)
+		}
+		h.Write([]byte(res.addr))
+	}
+
+	newHash := h.Sum64()
+	if s.routeHash != 0 && s.routeHash != newHash {
+		logger.Warn("route change detected", "host", s.Host, "hops", len(results))
+	}
+	s.routeHash = newHash
+}
+
+// resolveHopHostname runs the one reverse-DNS lookup recordHops allows
+// per hop and never retries on failure.
+func resolveHopHostname(hop *HopStats) {
+	names, err := net.LookupAddr(hop.Addr)
+	hop.mutex.Lock()
+	hop.resolving = false
+	if err != nil || len(names) == 0 {
+		hop.resolveFailed = true
+	} else {
+		hop.Hostname = names[0]
+	}
+	hop.mutex.Unlock()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+const tracerouteTableHeight = 12
+
+func hopTableHeader() []string {
+	return []string{"Hop", "Address", "Hostname", "Loss%", "Last", "Avg", "Best", "Worst", "StdDev"}
+}
+
+func hopTableRow(h *HopStats) []string {
+	h.mutex.Lock()
+	addr, hostname := h.Addr, h.Hostname
+	ttl := h.TTL
+	h.mutex.Unlock()
+
+	if addr == "" {
+		addr = "*"
+	}
+	return []string{
+		fmt.Sprintf("%d", ttl),
+		addr,
+		hostname,
+		fmt.Sprintf("%.1f%%", h.lossPercent()),
+		fmtMS(h.Last),
+		fmtMS(h.avg()),
+		fmtMS(h.Best),
+		fmtMS(h.Worst),
+		fmtMS(h.stddev()),
+	}
+}
+
+// displayTraceroute renders one hop table per host, stacked vertically,
+// refreshing at the same cadence as the regular plot UI. Falls back to
+// the CLI loop if the terminal doesn't support termui, same as
+// displayGraph.
+func displayTraceroute(stats []*HostStats) {
+	if err := termui.Init(); err != nil {
+		logger.Warn("graph display unavailable, falling back to CLI", "err", err)
+		displayLoop(stats)
+		return
+	}
+	uiMutex.Lock()
+	uiRunning = true
+	uiMutex.Unlock()
+	defer func() {
+		uiMutex.Lock()
+		uiRunning = false
+		uiMutex.Unlock()
+		termui.Close()
+	}()
+
+	tables := make([]*widgets.Table, len(stats))
+	for i, s := range stats {
+		t := widgets.NewTable()
+		t.Title = s.Host
+		t.SetRect(0, i*tracerouteTableHeight, 120, (i+1)*tracerouteTableHeight)
+		t.RowSeparator = false
+		t.FillRow = true
+		tables[i] = t
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	uiEvents := termui.PollEvents()
+	for run {
+		select {
+		case <-ticker.C:
+			drawables := make([]termui.Drawable, 0, len(stats))
+			for i, s := range stats {
+				s.hopsMutex.Lock()
+				rows := [][]string{hopTableHeader()}
+				for _, hop := range s.Hops {
+					rows = append(rows, hopTableRow(hop))
+				}
+				s.hopsMutex.Unlock()
+				tables[i].Rows = rows
+				drawables = append(drawables, tables[i])
+			}
+			termui.Render(drawables...)
+		case e := <-uiEvents:
+			if e.Type == termui.KeyboardEvent && e.ID == "q" {
+				run = false
+			}
+		}
+	}
+}